@@ -0,0 +1,133 @@
+package caddy_maxmind_geolocation
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// assetWrapper identifies the archive format an asset is packaged in, based on its file name.
+type assetWrapper int
+
+const (
+	wrapperNone assetWrapper = iota
+	wrapperGzip
+	wrapperTarGz
+	wrapperZip
+)
+
+// detectAssetWrapper determines the wrapper format from the asset's name (and, failing that, its
+// Content-Type), the way upstream mirrors of MaxMind's databases typically package releases.
+func detectAssetWrapper(assetName, contentType string) assetWrapper {
+	lower := strings.ToLower(assetName)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz"):
+		return wrapperTarGz
+	case strings.HasSuffix(lower, ".gz"):
+		return wrapperGzip
+	case strings.HasSuffix(lower, ".zip"):
+		return wrapperZip
+	}
+	switch contentType {
+	case "application/gzip", "application/x-gzip":
+		return wrapperGzip
+	case "application/zip":
+		return wrapperZip
+	}
+	return wrapperNone
+}
+
+// extractGzip decompresses a single-file gzip stream straight to destPath.
+func extractGzip(r io.Reader, destPath string, validate func(string) error) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("gunzip: %w", err)
+	}
+	defer gz.Close()
+	return atomicWriteReader(destPath, gz, validate)
+}
+
+// extractTarGz scans a .tar.gz stream for the first entry whose base name matches innerGlob and
+// writes only that entry to destPath.
+func extractTarGz(r io.Reader, innerGlob, destPath string, validate func(string) error) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("gunzip: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("tar.gz: no entry matching %q found", innerGlob)
+		}
+		if err != nil {
+			return fmt.Errorf("tar.gz read: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if matched, _ := path.Match(innerGlob, path.Base(hdr.Name)); !matched {
+			continue
+		}
+		return atomicWriteReader(destPath, tr, validate)
+	}
+}
+
+// extractZip scans a .zip stream for the first entry whose base name matches innerGlob and
+// writes only that entry to destPath. zip.Reader requires an io.ReaderAt, so the body is first
+// buffered to a temp file.
+func extractZip(r io.Reader, innerGlob, destPath string, validate func(string) error) error {
+	buf, err := os.CreateTemp(filepath.Dir(destPath), filepath.Base(destPath)+".zip.*")
+	if err != nil {
+		return fmt.Errorf("zip buffer: %w", err)
+	}
+	defer os.Remove(buf.Name())
+	defer buf.Close()
+
+	size, err := io.Copy(buf, r)
+	if err != nil {
+		return fmt.Errorf("zip buffer write: %w", err)
+	}
+
+	zr, err := zip.NewReader(buf, size)
+	if err != nil {
+		return fmt.Errorf("zip open: %w", err)
+	}
+	for _, f := range zr.File {
+		if matched, _ := path.Match(innerGlob, path.Base(f.Name)); !matched {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("zip entry open: %w", err)
+		}
+		defer rc.Close()
+		return atomicWriteReader(destPath, rc, validate)
+	}
+	return fmt.Errorf("zip: no entry matching %q found", innerGlob)
+}
+
+// extractAsset writes r to destPath, transparently unwrapping the archive format detected from
+// assetName/contentType. innerGlob selects which file to keep from multi-file archives (tar.gz,
+// zip); it is ignored for plain files and single-file gzip streams. validate, if non-nil, is run
+// against the written file before it's moved into place; see atomicWriteReader.
+func extractAsset(r io.Reader, assetName, contentType, innerGlob, destPath string, validate func(string) error) error {
+	switch detectAssetWrapper(assetName, contentType) {
+	case wrapperGzip:
+		return extractGzip(r, destPath, validate)
+	case wrapperTarGz:
+		return extractTarGz(r, innerGlob, destPath, validate)
+	case wrapperZip:
+		return extractZip(r, innerGlob, destPath, validate)
+	default:
+		return atomicWriteReader(destPath, r, validate)
+	}
+}
@@ -0,0 +1,116 @@
+package caddy_maxmind_geolocation
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectAssetWrapper(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		want        assetWrapper
+	}{
+		{"GeoLite2-Country.mmdb", "", wrapperNone},
+		{"GeoLite2-Country.mmdb.gz", "", wrapperGzip},
+		{"GeoLite2-Country_20260225.tar.gz", "", wrapperTarGz},
+		{"geolite2.tgz", "", wrapperTarGz},
+		{"GeoLite2-Country.zip", "", wrapperZip},
+		{"asset-without-extension", "application/gzip", wrapperGzip},
+		{"asset-without-extension", "application/zip", wrapperZip},
+	}
+	for _, tt := range tests {
+		if got := detectAssetWrapper(tt.name, tt.contentType); got != tt.want {
+			t.Errorf("detectAssetWrapper(%q, %q) = %v, want %v", tt.name, tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestExtractAsset_Gzip(t *testing.T) {
+	content := []byte("fake mmdb content")
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	gw.Write(content)
+	gw.Close()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "db.mmdb")
+	if err := extractAsset(&buf, "db.mmdb.gz", "", "", dest, nil); err != nil {
+		t.Fatalf("extractAsset: %v", err)
+	}
+	got, _ := os.ReadFile(dest)
+	if string(got) != string(content) {
+		t.Errorf("content = %q, want %q", got, content)
+	}
+}
+
+func TestExtractAsset_TarGz(t *testing.T) {
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	files := map[string]string{
+		"GeoLite2-Country_20260225/README.txt":            "not the db",
+		"GeoLite2-Country_20260225/GeoLite2-Country.mmdb": "fake mmdb content",
+	}
+	for name, content := range files {
+		tw.WriteHeader(&tar.Header{Name: name, Size: int64(len(content)), Mode: 0644})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+
+	var gzBuf bytes.Buffer
+	gw := gzip.NewWriter(&gzBuf)
+	gw.Write(tarBuf.Bytes())
+	gw.Close()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "db.mmdb")
+	if err := extractAsset(&gzBuf, "GeoLite2-Country_20260225.tar.gz", "", "*.mmdb", dest, nil); err != nil {
+		t.Fatalf("extractAsset: %v", err)
+	}
+	got, _ := os.ReadFile(dest)
+	if string(got) != "fake mmdb content" {
+		t.Errorf("content = %q, want fake mmdb content", got)
+	}
+}
+
+func TestExtractAsset_Zip(t *testing.T) {
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	w, _ := zw.Create("GeoLite2-Country.mmdb")
+	w.Write([]byte("fake mmdb content"))
+	zw.Close()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "db.mmdb")
+	if err := extractAsset(bytes.NewReader(zipBuf.Bytes()), "GeoLite2-Country.zip", "", "*.mmdb", dest, nil); err != nil {
+		t.Fatalf("extractAsset: %v", err)
+	}
+	got, _ := os.ReadFile(dest)
+	if string(got) != "fake mmdb content" {
+		t.Errorf("content = %q, want fake mmdb content", got)
+	}
+}
+
+func TestExtractAsset_TarGzNoMatch(t *testing.T) {
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	tw.WriteHeader(&tar.Header{Name: "README.txt", Size: 5, Mode: 0644})
+	tw.Write([]byte("hello"))
+	tw.Close()
+
+	var gzBuf bytes.Buffer
+	gw := gzip.NewWriter(&gzBuf)
+	gw.Write(tarBuf.Bytes())
+	gw.Close()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "db.mmdb")
+	if err := extractAsset(&gzBuf, "release.tar.gz", "", "*.mmdb", dest, nil); err == nil {
+		t.Error("expected error when no entry matches innerGlob")
+	}
+}
@@ -0,0 +1,165 @@
+package caddy_maxmind_geolocation
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// AssetMatchMode selects how an asset pattern string is interpreted when picking a release asset.
+type AssetMatchMode string
+
+const (
+	MatchLiteral AssetMatchMode = ""      // exact name match (default, backwards compatible)
+	MatchGlob    AssetMatchMode = "glob"  // path.Match-style glob, e.g. "GeoLite2-Country*.tar.gz"
+	MatchRegex   AssetMatchMode = "regex" // regexp.MatchString against the asset name
+)
+
+// AssetSelector picks one asset out of a release's asset list.
+//
+// Its fields map one-to-one onto the Caddyfile directives asset_pattern, asset_match_mode and
+// prefer, and are meant to be populated by a module's UnmarshalCaddyfile once one exists. As it
+// stands, this tree has no module config struct for those directives to be parsed into, so an
+// AssetSelector can only be constructed directly from Go code, not from a Caddyfile.
+type AssetSelector struct {
+	// Pattern is a literal name, glob, or regex depending on Mode. It may also be a Go template
+	// (detected by the presence of "{{"), evaluated against .GOOS, .GOARCH and .Tag before being
+	// used as the literal/glob/regex pattern; a "tagMatch <regex> <group>" template func is
+	// available to pull a capture group out of the release tag. <regex> is parsed by text/template
+	// using Go string-literal rules, so a regex containing backslash escapes (e.g. \d, \.) must be
+	// written as a backtick raw string — {{ tagMatch `v(\d+\.\d+\.\d+)` 1 }} — not a double-quoted
+	// one, where \d is an invalid Go escape.
+	Pattern string
+	Mode    AssetMatchMode
+	// Prefer breaks ties when Pattern matches more than one asset: "semver" compares the highest
+	// dotted version number found in each name, "lexical" (the default, for "" or any other
+	// value) picks the name that sorts highest.
+	Prefer string
+}
+
+// assetPatternData is the template data available to an AssetSelector.Pattern template.
+type assetPatternData struct {
+	GOOS   string
+	GOARCH string
+	Tag    string
+}
+
+func assetPatternFuncs(tag string) template.FuncMap {
+	return template.FuncMap{
+		"tagMatch": func(pattern string, group int) (string, error) {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return "", fmt.Errorf("tagMatch: invalid regex %q: %w", pattern, err)
+			}
+			m := re.FindStringSubmatch(tag)
+			if m == nil || group >= len(m) {
+				return "", fmt.Errorf("tagMatch: pattern %q does not match tag %q", pattern, tag)
+			}
+			return m[group], nil
+		},
+	}
+}
+
+// renderAssetPattern evaluates pattern as a Go template against tag if it looks like one
+// (contains "{{"), otherwise returns it unchanged.
+func renderAssetPattern(pattern, tag string) (string, error) {
+	if !strings.Contains(pattern, "{{") {
+		return pattern, nil
+	}
+	tmpl, err := template.New("asset_pattern").Funcs(assetPatternFuncs(tag)).Parse(pattern)
+	if err != nil {
+		return "", fmt.Errorf("asset_pattern template: %w", err)
+	}
+	var buf strings.Builder
+	data := assetPatternData{GOOS: runtime.GOOS, GOARCH: runtime.GOARCH, Tag: tag}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("asset_pattern template exec: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// selectAsset picks the asset from assets matching sel, resolving ambiguity per sel.Prefer.
+// It returns an error listing the considered asset names if nothing matches.
+func selectAsset(assets []githubAsset, sel AssetSelector, tag string) (githubAsset, error) {
+	pattern, err := renderAssetPattern(sel.Pattern, tag)
+	if err != nil {
+		return githubAsset{}, err
+	}
+
+	var matches []githubAsset
+	switch sel.Mode {
+	case MatchGlob:
+		for _, a := range assets {
+			if ok, _ := path.Match(pattern, a.Name); ok {
+				matches = append(matches, a)
+			}
+		}
+	case MatchRegex:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return githubAsset{}, fmt.Errorf("asset_pattern: invalid regex %q: %w", pattern, err)
+		}
+		for _, a := range assets {
+			if re.MatchString(a.Name) {
+				matches = append(matches, a)
+			}
+		}
+	default:
+		for _, a := range assets {
+			if a.Name == pattern {
+				matches = append(matches, a)
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		return githubAsset{}, fmt.Errorf("no asset matching %q found (assetNames: %v)", sel.Pattern, assetNames(assets))
+	}
+	best := matches[0]
+	for _, a := range matches[1:] {
+		if preferSecond(best.Name, a.Name, sel.Prefer) {
+			best = a
+		}
+	}
+	return best, nil
+}
+
+// preferSecond reports whether b should be preferred over a, per prefer ("semver" or "lexical").
+func preferSecond(a, b, prefer string) bool {
+	if prefer == "semver" {
+		if va, ok := parseSemverish(a); ok {
+			if vb, ok := parseSemverish(b); ok {
+				return compareVersions(va, vb) < 0
+			}
+		}
+	}
+	return strings.Compare(a, b) < 0
+}
+
+var semverishPattern = regexp.MustCompile(`(\d+)\.(\d+)\.(\d+)`)
+
+// parseSemverish extracts the first dotted major.minor.patch number found in s.
+func parseSemverish(s string) ([3]int, bool) {
+	m := semverishPattern.FindStringSubmatch(s)
+	if m == nil {
+		return [3]int{}, false
+	}
+	var v [3]int
+	for i := range v {
+		v[i], _ = strconv.Atoi(m[i+1])
+	}
+	return v, true
+}
+
+func compareVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+package caddy_maxmind_geolocation
+
+import (
+	"runtime"
+	"testing"
+)
+
+func assets(names ...string) []githubAsset {
+	out := make([]githubAsset, len(names))
+	for i, n := range names {
+		out[i] = githubAsset{Name: n, BrowserDownloadURL: "https://example.com/" + n}
+	}
+	return out
+}
+
+func TestSelectAsset_Literal(t *testing.T) {
+	a, err := selectAsset(assets("GeoLite2-Country.mmdb", "GeoLite2-City.mmdb"), AssetSelector{Pattern: "GeoLite2-Country.mmdb"}, "v1.0.0")
+	if err != nil {
+		t.Fatalf("selectAsset: %v", err)
+	}
+	if a.Name != "GeoLite2-Country.mmdb" {
+		t.Errorf("Name = %q", a.Name)
+	}
+}
+
+func TestSelectAsset_Glob(t *testing.T) {
+	a, err := selectAsset(assets("GeoLite2-Country_20260225.mmdb.tar.gz", "GeoLite2-City_20260225.mmdb.tar.gz"),
+		AssetSelector{Pattern: "GeoLite2-Country_*.tar.gz", Mode: MatchGlob}, "v1.0.0")
+	if err != nil {
+		t.Fatalf("selectAsset: %v", err)
+	}
+	if a.Name != "GeoLite2-Country_20260225.mmdb.tar.gz" {
+		t.Errorf("Name = %q", a.Name)
+	}
+}
+
+func TestSelectAsset_Regex(t *testing.T) {
+	a, err := selectAsset(assets("GeoLite2-Country_20260225.mmdb.tar.gz", "README.md"),
+		AssetSelector{Pattern: `^GeoLite2-Country_\d+\.mmdb\.tar\.gz$`, Mode: MatchRegex}, "v1.0.0")
+	if err != nil {
+		t.Fatalf("selectAsset: %v", err)
+	}
+	if a.Name != "GeoLite2-Country_20260225.mmdb.tar.gz" {
+		t.Errorf("Name = %q", a.Name)
+	}
+}
+
+func TestSelectAsset_NoMatch(t *testing.T) {
+	_, err := selectAsset(assets("GeoLite2-City.mmdb"), AssetSelector{Pattern: "GeoLite2-Country.mmdb"}, "v1.0.0")
+	if err == nil {
+		t.Fatal("expected error for no match")
+	}
+}
+
+func TestSelectAsset_AmbiguousPrefersLexical(t *testing.T) {
+	a, err := selectAsset(assets("geo_v1.mmdb", "geo_v2.mmdb"), AssetSelector{Pattern: "geo_v*.mmdb", Mode: MatchGlob}, "v1.0.0")
+	if err != nil {
+		t.Fatalf("selectAsset: %v", err)
+	}
+	if a.Name != "geo_v2.mmdb" {
+		t.Errorf("Name = %q, want geo_v2.mmdb (lexically highest)", a.Name)
+	}
+}
+
+func TestSelectAsset_AmbiguousPrefersSemver(t *testing.T) {
+	a, err := selectAsset(
+		assets("geo-1.9.0.mmdb.tar.gz", "geo-1.10.0.mmdb.tar.gz", "geo-1.2.0.mmdb.tar.gz"),
+		AssetSelector{Pattern: "geo-*.mmdb.tar.gz", Mode: MatchGlob, Prefer: "semver"},
+		"v1.0.0",
+	)
+	if err != nil {
+		t.Fatalf("selectAsset: %v", err)
+	}
+	if a.Name != "geo-1.10.0.mmdb.tar.gz" {
+		t.Errorf("Name = %q, want geo-1.10.0.mmdb.tar.gz (highest semver)", a.Name)
+	}
+}
+
+func TestSelectAsset_Template(t *testing.T) {
+	want := "geo-" + runtime.GOOS + "-" + runtime.GOARCH + ".mmdb"
+	a, err := selectAsset(assets(want, "geo-other-other.mmdb"),
+		AssetSelector{Pattern: "geo-{{.GOOS}}-{{.GOARCH}}.mmdb"}, "v1.0.0")
+	if err != nil {
+		t.Fatalf("selectAsset: %v", err)
+	}
+	if a.Name != want {
+		t.Errorf("Name = %q, want %q", a.Name, want)
+	}
+}
+
+func TestSelectAsset_TagMatchTemplate(t *testing.T) {
+	a, err := selectAsset(assets("GeoLite2-Country_2026.02.25.mmdb"), AssetSelector{
+		Pattern: "GeoLite2-Country_{{ tagMatch `v(\\d+\\.\\d+\\.\\d+)` 1 }}.mmdb",
+	}, "v2026.02.25")
+	if err != nil {
+		t.Fatalf("selectAsset: %v", err)
+	}
+	if a.Name != "GeoLite2-Country_2026.02.25.mmdb" {
+		t.Errorf("Name = %q", a.Name)
+	}
+}
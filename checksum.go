@@ -0,0 +1,158 @@
+package caddy_maxmind_geolocation
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// checksumSiblings lists the sibling-asset suffixes checked for a release's checksum file, in
+// preference order, alongside the hash they're expected to contain.
+var checksumSiblings = []struct {
+	suffix  string
+	newHash func() hash.Hash
+}{
+	{".sha256", sha256.New},
+	{".md5", md5.New},
+}
+
+// findChecksumAsset looks for a sibling asset named assetName+".sha256" or assetName+".md5" in
+// assets and returns its download URL and hasher constructor.
+func findChecksumAsset(assets []githubAsset, assetName string) (downloadURL string, newHash func() hash.Hash, ok bool) {
+	for _, cs := range checksumSiblings {
+		if a, found := findAsset(assets, assetName+cs.suffix); found {
+			return a.BrowserDownloadURL, cs.newHash, true
+		}
+	}
+	return "", nil, false
+}
+
+// fetchChecksum downloads a sibling checksum file and extracts the hex digest. Checksum files
+// conventionally contain either a bare hex digest or "<hex digest>  <filename>".
+func fetchChecksum(downloadURL, token string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return "", err
+	}
+	if token != "" && strings.Contains(downloadURL, "api.github.com") {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := githubHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("checksum download: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("checksum download %s: %s", resp.Status, string(body))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", fmt.Errorf("checksum read: %w", err)
+	}
+	fields := strings.Fields(string(body))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("checksum file is empty")
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// checksumValidator returns a validate func (for atomicWriteReader) that rejects the file unless
+// hashing it with newHash produces expectedHex. Use this when the expected hash describes the
+// file atomicWriteReader is given — e.g. MaxMind's db_md5 is documented as the hash of the
+// decompressed database, which is exactly what downloadMaxMindDB passes it. For a checksum that
+// instead describes a wrapping archive (a GitHub release's sibling .sha256/.md5 asset, or
+// ExpectedSHA256), use outerChecksumTee in downloadFile instead, since the archive is no longer
+// available once atomicWriteReader receives the extracted member.
+func checksumValidator(newHash func() hash.Hash, expectedHex string) func(tmpPath string) error {
+	return func(tmpPath string) error {
+		f, err := os.Open(tmpPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		h := newHash()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		got := hex.EncodeToString(h.Sum(nil))
+		if !strings.EqualFold(got, expectedHex) {
+			return fmt.Errorf("checksum mismatch: got %s, want %s", got, expectedHex)
+		}
+		return nil
+	}
+}
+
+// outerChecksum describes a checksum that must be verified against the raw downloaded bytes
+// before any archive extraction — as opposed to checksumValidator, which checks a file already
+// sitting on disk (suitable for the extracted/decompressed result, not the archive it came from).
+type outerChecksum struct {
+	newHash     func() hash.Hash
+	expectedHex string
+}
+
+// checksumTee wraps r so that everything read through it is hashed incrementally. Call verify
+// once the wrapped reader has been fully consumed (e.g. after extractAsset returns) to check the
+// result against expectedHex.
+type checksumTee struct {
+	io.Reader
+	hash        hash.Hash
+	expectedHex string
+}
+
+func newChecksumTee(r io.Reader, c outerChecksum) *checksumTee {
+	h := c.newHash()
+	return &checksumTee{Reader: io.TeeReader(r, h), hash: h, expectedHex: c.expectedHex}
+}
+
+func (c *checksumTee) verify() error {
+	got := hex.EncodeToString(c.hash.Sum(nil))
+	if !strings.EqualFold(got, c.expectedHex) {
+		return fmt.Errorf("checksum mismatch: got %s, want %s", got, c.expectedHex)
+	}
+	return nil
+}
+
+// dbTypeValidator returns a validate func (for atomicWriteReader) that rejects the file unless it
+// opens as a maxminddb whose Metadata.DatabaseType equals expectedType.
+func dbTypeValidator(expectedType string) func(tmpPath string) error {
+	return func(tmpPath string) error {
+		db, err := maxminddb.Open(tmpPath)
+		if err != nil {
+			return fmt.Errorf("open as maxmind db: %w", err)
+		}
+		defer db.Close()
+		if db.Metadata.DatabaseType != expectedType {
+			return fmt.Errorf("database type %q does not match expected %q", db.Metadata.DatabaseType, expectedType)
+		}
+		return nil
+	}
+}
+
+// resolveOuterChecksum decides which checksum, if any, the raw downloaded bytes for assetName
+// must match: expectedSHA256 if set, else a sibling checksum asset in the release if one exists.
+// Returns a nil *outerChecksum if neither applies.
+func resolveOuterChecksum(assets []githubAsset, assetName, token, expectedSHA256 string) (*outerChecksum, error) {
+	if expectedSHA256 != "" {
+		return &outerChecksum{newHash: sha256.New, expectedHex: expectedSHA256}, nil
+	}
+	if checksumURL, newHash, ok := findChecksumAsset(assets, assetName); ok {
+		expected, err := fetchChecksum(checksumURL, token)
+		if err != nil {
+			return nil, fmt.Errorf("fetch checksum for %q: %w", assetName, err)
+		}
+		return &outerChecksum{newHash: newHash, expectedHex: expected}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,188 @@
+package caddy_maxmind_geolocation
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSyncFromGitHubRelease_ChecksumSibling(t *testing.T) {
+	fakeContent := []byte("fake mmdb content")
+	sum := sha256.Sum256(fakeContent)
+	sumHex := hex.EncodeToString(sum[:])
+
+	release := githubReleaseResponse{
+		TagName: "v1.0.0",
+		Assets: []githubAsset{
+			{Name: "GeoLite2-Country.mmdb"},
+			{Name: "GeoLite2-Country.mmdb.sha256"},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/P3TERX/GeoLite.mmdb/releases/latest":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(release)
+		case "/asset":
+			w.Write(fakeContent)
+		case "/asset.sha256":
+			w.Write([]byte(sumHex + "  GeoLite2-Country.mmdb\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	release.Assets[0].BrowserDownloadURL = server.URL + "/asset"
+	release.Assets[1].BrowserDownloadURL = server.URL + "/asset.sha256"
+
+	oldBase := githubAPIBaseURL
+	oldClient := githubHTTPClient
+	githubAPIBaseURL = server.URL
+	githubHTTPClient = server.Client()
+	defer func() {
+		githubAPIBaseURL = oldBase
+		githubHTTPClient = oldClient
+	}()
+
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "GeoLite2-Country.mmdb")
+
+	tag, updated, err := syncFromGitHubRelease("P3TERX/GeoLite.mmdb", "GeoLite2-Country.mmdb", cachePath, "", SyncOptions{})
+	if err != nil {
+		t.Fatalf("syncFromGitHubRelease: %v", err)
+	}
+	if tag != "v1.0.0" || !updated {
+		t.Fatalf("tag = %q, updated = %v", tag, updated)
+	}
+	got, _ := os.ReadFile(cachePath)
+	if string(got) != string(fakeContent) {
+		t.Errorf("content = %q, want %q", got, fakeContent)
+	}
+}
+
+// TestSyncFromGitHubRelease_ChecksumTarGz verifies ExpectedSHA256 is checked against the raw
+// .tar.gz asset as published, not the .mmdb extracted from it — the two differ for any archived
+// asset, so a checksum that only matched the extracted file would reject every real download.
+func TestSyncFromGitHubRelease_ChecksumTarGz(t *testing.T) {
+	innerContent := []byte("fake mmdb content")
+
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	tw.WriteHeader(&tar.Header{Name: "GeoLite2-Country.mmdb", Size: int64(len(innerContent)), Mode: 0644})
+	tw.Write(innerContent)
+	tw.Close()
+
+	var archive bytes.Buffer
+	gw := gzip.NewWriter(&archive)
+	gw.Write(tarBuf.Bytes())
+	gw.Close()
+	archiveBytes := archive.Bytes()
+
+	outerSum := sha256.Sum256(archiveBytes)
+	outerSumHex := hex.EncodeToString(outerSum[:])
+
+	release := githubReleaseResponse{
+		TagName: "v1.0.0",
+		Assets:  []githubAsset{{Name: "GeoLite2-Country.tar.gz"}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/P3TERX/GeoLite.mmdb/releases/latest":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(release)
+		case "/asset":
+			w.Write(archiveBytes)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	release.Assets[0].BrowserDownloadURL = server.URL + "/asset"
+
+	oldBase := githubAPIBaseURL
+	oldClient := githubHTTPClient
+	githubAPIBaseURL = server.URL
+	githubHTTPClient = server.Client()
+	defer func() {
+		githubAPIBaseURL = oldBase
+		githubHTTPClient = oldClient
+	}()
+
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "GeoLite2-Country.mmdb")
+
+	opts := SyncOptions{InnerGlob: "*.mmdb", ExpectedSHA256: outerSumHex}
+	tag, updated, err := syncFromGitHubRelease("P3TERX/GeoLite.mmdb", "GeoLite2-Country.tar.gz", cachePath, "", opts)
+	if err != nil {
+		t.Fatalf("syncFromGitHubRelease: %v", err)
+	}
+	if tag != "v1.0.0" || !updated {
+		t.Fatalf("tag = %q, updated = %v", tag, updated)
+	}
+	got, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != string(innerContent) {
+		t.Errorf("content = %q, want %q", got, innerContent)
+	}
+}
+
+func TestSyncFromGitHubRelease_ChecksumMismatch(t *testing.T) {
+	fakeContent := []byte("fake mmdb content")
+
+	release := githubReleaseResponse{
+		TagName: "v1.0.0",
+		Assets: []githubAsset{
+			{Name: "GeoLite2-Country.mmdb"},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/P3TERX/GeoLite.mmdb/releases/latest":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(release)
+		case "/asset":
+			w.Write(fakeContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	release.Assets[0].BrowserDownloadURL = server.URL + "/asset"
+
+	oldBase := githubAPIBaseURL
+	oldClient := githubHTTPClient
+	githubAPIBaseURL = server.URL
+	githubHTTPClient = server.Client()
+	defer func() {
+		githubAPIBaseURL = oldBase
+		githubHTTPClient = oldClient
+	}()
+
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "GeoLite2-Country.mmdb")
+
+	wrongSHA := "0000000000000000000000000000000000000000000000000000000000000000"[:64]
+	_, _, err := syncFromGitHubRelease("P3TERX/GeoLite.mmdb", "GeoLite2-Country.mmdb", cachePath, "", SyncOptions{ExpectedSHA256: wrongSHA})
+	if err == nil {
+		t.Fatal("expected checksum mismatch error")
+	}
+	if _, statErr := os.Stat(cachePath); !os.IsNotExist(statErr) {
+		t.Error("cache file should not exist after a checksum mismatch")
+	}
+}
@@ -0,0 +1,135 @@
+package caddy_maxmind_geolocation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// RateLimitError reports that a GitHub API request was rejected due to rate limiting, carrying
+// enough information (from the X-RateLimit-Remaining/X-RateLimit-Reset response headers) for a
+// caller to back off politely instead of hammering the API on the next poll.
+type RateLimitError struct {
+	Remaining int
+	ResetAt   time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("github API rate limit exceeded: remaining=%d reset=%s", e.Remaining, e.ResetAt.Format(time.RFC3339))
+}
+
+// rateLimitErrorFromResponse builds a RateLimitError from resp's rate-limit headers, or returns
+// nil if resp doesn't indicate an exhausted rate limit.
+func rateLimitErrorFromResponse(resp *http.Response) error {
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(remaining)
+	if err != nil || n > 0 {
+		return nil
+	}
+	var resetAt time.Time
+	if reset := resp.Header.Get("X-RateLimit-Reset"); reset != "" {
+		if sec, err := strconv.ParseInt(reset, 10, 64); err == nil {
+			resetAt = time.Unix(sec, 0)
+		}
+	}
+	return &RateLimitError{Remaining: n, ResetAt: resetAt}
+}
+
+// SetGitHubTransport lets Caddy operators inject a custom http.RoundTripper (e.g. a shared,
+// caching one) for GitHub API and download requests, instead of always using http.DefaultClient.
+// Passing nil restores the default client.
+func SetGitHubTransport(rt http.RoundTripper) {
+	if rt == nil {
+		githubHTTPClient = http.DefaultClient
+		return
+	}
+	githubHTTPClient = &http.Client{Transport: rt}
+}
+
+// etagCacheEntry is what's persisted in a cachePath+".etag" file: the ETag of the last successful
+// response alongside the decoded release it described, so a future 304 Not Modified can be served
+// without decoding a body.
+type etagCacheEntry struct {
+	ETag    string                `json:"etag"`
+	Release githubReleaseResponse `json:"release"`
+}
+
+func etagPath(cachePath string) string {
+	return cachePath + ".etag"
+}
+
+func readETagCache(cachePath string) (etag string, release *githubReleaseResponse, ok bool) {
+	b, err := os.ReadFile(etagPath(cachePath))
+	if err != nil {
+		return "", nil, false
+	}
+	var entry etagCacheEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return "", nil, false
+	}
+	return entry.ETag, &entry.Release, true
+}
+
+func writeETagCache(cachePath, etag string, release *githubReleaseResponse) error {
+	b, err := json.Marshal(etagCacheEntry{ETag: etag, Release: *release})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(etagPath(cachePath), b, 0644)
+}
+
+// fetchLatestReleaseCached is like fetchLatestRelease, but sends an If-None-Match built from a
+// previous response's ETag (cached alongside cachePath) and, on 304 Not Modified, returns the
+// cached release without decoding a body — sparing an unchanged poll its rate-limit token.
+func fetchLatestReleaseCached(repo, token, cachePath string) (*githubReleaseResponse, error) {
+	owner, name, err := parseRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/releases/latest", githubAPIBaseURL, owner, name)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	setGitHubHeaders(req, token)
+
+	cachedETag, cachedRelease, hasCached := readETagCache(cachePath)
+	if hasCached {
+		req.Header.Set("If-None-Match", cachedETag)
+	}
+
+	resp, err := githubHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("github request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		return cachedRelease, nil
+	}
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		if rlErr := rateLimitErrorFromResponse(resp); rlErr != nil {
+			return nil, rlErr
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("github API %s: %s", resp.Status, string(body))
+	}
+
+	var release githubReleaseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("github response decode: %w", err)
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		_ = writeETagCache(cachePath, etag, &release)
+	}
+	return &release, nil
+}
@@ -0,0 +1,113 @@
+package caddy_maxmind_geolocation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestFetchLatestReleaseCached_ETag(t *testing.T) {
+	release := githubReleaseResponse{
+		TagName: "v1.0.0",
+		Assets:  []githubAsset{{Name: "GeoLite2-Country.mmdb", BrowserDownloadURL: "https://example.com/country.mmdb"}},
+	}
+	body, _ := json.Marshal(release)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1-etag"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1-etag"`)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	oldBase := githubAPIBaseURL
+	oldClient := githubHTTPClient
+	githubAPIBaseURL = server.URL
+	githubHTTPClient = server.Client()
+	defer func() {
+		githubAPIBaseURL = oldBase
+		githubHTTPClient = oldClient
+	}()
+
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "GeoLite2-Country.mmdb")
+
+	got, err := fetchLatestReleaseCached("P3TERX/GeoLite.mmdb", "", cachePath)
+	if err != nil {
+		t.Fatalf("first fetch: %v", err)
+	}
+	if got.TagName != "v1.0.0" {
+		t.Errorf("tag = %q, want v1.0.0", got.TagName)
+	}
+	if requests != 1 {
+		t.Fatalf("requests = %d, want 1", requests)
+	}
+
+	got2, err := fetchLatestReleaseCached("P3TERX/GeoLite.mmdb", "", cachePath)
+	if err != nil {
+		t.Fatalf("second fetch: %v", err)
+	}
+	if got2.TagName != "v1.0.0" {
+		t.Errorf("cached tag = %q, want v1.0.0", got2.TagName)
+	}
+	if requests != 2 {
+		t.Fatalf("requests = %d, want 2", requests)
+	}
+}
+
+func TestFetchLatestReleaseCached_RateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "1800000000")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("rate limited"))
+	}))
+	defer server.Close()
+
+	oldBase := githubAPIBaseURL
+	oldClient := githubHTTPClient
+	githubAPIBaseURL = server.URL
+	githubHTTPClient = server.Client()
+	defer func() {
+		githubAPIBaseURL = oldBase
+		githubHTTPClient = oldClient
+	}()
+
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "GeoLite2-Country.mmdb")
+
+	_, err := fetchLatestReleaseCached("P3TERX/GeoLite.mmdb", "", cachePath)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	rlErr, ok := err.(*RateLimitError)
+	if !ok {
+		t.Fatalf("error = %T, want *RateLimitError", err)
+	}
+	if rlErr.Remaining != 0 {
+		t.Errorf("Remaining = %d, want 0", rlErr.Remaining)
+	}
+}
+
+func TestSetGitHubTransport(t *testing.T) {
+	oldClient := githubHTTPClient
+	defer func() { githubHTTPClient = oldClient }()
+
+	SetGitHubTransport(http.DefaultTransport)
+	if githubHTTPClient == http.DefaultClient {
+		t.Error("expected a non-default client after SetGitHubTransport")
+	}
+
+	SetGitHubTransport(nil)
+	if githubHTTPClient != http.DefaultClient {
+		t.Error("expected default client after SetGitHubTransport(nil)")
+	}
+}
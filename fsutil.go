@@ -0,0 +1,45 @@
+package caddy_maxmind_geolocation
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// atomicWriteReader copies r to destPath via a temp file in the same directory followed by a
+// rename, so a reader failing partway through never leaves a corrupt file at destPath. If
+// validate is non-nil, it runs against the temp file's path after the copy completes and before
+// the rename; a non-nil error aborts the write (deleting the temp file) without ever touching
+// destPath, so a corrupted or unexpected download never replaces a working cache file.
+func atomicWriteReader(destPath string, r io.Reader, validate func(tmpPath string) error) error {
+	dir := filepath.Dir(destPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+	// Remove stale temp files from previous runs (e.g. crashed before rename).
+	cleanupStaleTempFiles(dir, filepath.Base(destPath))
+	tmp, err := os.CreateTemp(dir, filepath.Base(destPath)+".*")
+	if err != nil {
+		return fmt.Errorf("temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if validate != nil {
+		if err := validate(tmpPath); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+	return nil
+}
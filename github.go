@@ -33,35 +33,43 @@ type githubAsset struct {
 	BrowserDownloadURL string `json:"browser_download_url"`
 }
 
-// fetchLatestReleaseTagAndAssetURL returns tag name and download URL for the given asset name.
-func fetchLatestReleaseTagAndAssetURL(repo, assetName, token string) (tag string, downloadURL string, err error) {
+// fetchLatestRelease fetches the latest release (tag and asset list) for repo.
+func fetchLatestRelease(repo, token string) (*githubReleaseResponse, error) {
 	owner, name, err := parseRepo(repo)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 	url := fmt.Sprintf("%s/repos/%s/%s/releases/latest", githubAPIBaseURL, owner, name)
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 	setGitHubHeaders(req, token)
 
 	resp, err := githubHTTPClient.Do(req)
 	if err != nil {
-		return "", "", fmt.Errorf("github request: %w", err)
+		return nil, fmt.Errorf("github request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
-		return "", "", fmt.Errorf("github API %s: %s", resp.Status, string(body))
+		return nil, fmt.Errorf("github API %s: %s", resp.Status, string(body))
 	}
 
 	var release githubReleaseResponse
 	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return "", "", fmt.Errorf("github response decode: %w", err)
+		return nil, fmt.Errorf("github response decode: %w", err)
 	}
+	return &release, nil
+}
 
+// fetchLatestReleaseTagAndAssetURL returns tag name and download URL for the given asset name.
+func fetchLatestReleaseTagAndAssetURL(repo, assetName, token string) (tag string, downloadURL string, err error) {
+	release, err := fetchLatestRelease(repo, token)
+	if err != nil {
+		return "", "", err
+	}
 	for _, a := range release.Assets {
 		if a.Name == assetName {
 			return release.TagName, a.BrowserDownloadURL, nil
@@ -70,6 +78,16 @@ func fetchLatestReleaseTagAndAssetURL(repo, assetName, token string) (tag string
 	return "", "", fmt.Errorf("asset %q not found in release %s (assets: %v)", assetName, release.TagName, assetNames(release.Assets))
 }
 
+// findAsset returns the asset named name from assets, if present.
+func findAsset(assets []githubAsset, name string) (githubAsset, bool) {
+	for _, a := range assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return githubAsset{}, false
+}
+
 func assetNames(a []githubAsset) []string {
 	names := make([]string, len(a))
 	for i := range a {
@@ -94,15 +112,27 @@ func setGitHubHeaders(req *http.Request, token string) {
 	}
 }
 
+// sidecarSuffixes lists the cache sidecar files kept alongside a cache file (e.g. cache.mmdb.tag),
+// which cleanupStaleTempFiles must not mistake for leftover CreateTemp files.
+var sidecarSuffixes = []string{".tag", ".md5", ".etag"}
+
+func isSidecarFile(name, baseName string) bool {
+	for _, suffix := range sidecarSuffixes {
+		if name == baseName+suffix {
+			return true
+		}
+	}
+	return false
+}
+
 // cleanupStaleTempFiles removes temp files in dir with prefix baseName+"."
-// (leftover from CreateTemp), but keeps baseName and baseName+".tag".
+// (leftover from CreateTemp), but keeps baseName and its sidecar files.
 func cleanupStaleTempFiles(dir, baseName string) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return
 	}
 	prefix := baseName + "."
-	tagFile := baseName + ".tag"
 	for _, e := range entries {
 		if e.IsDir() {
 			continue
@@ -111,15 +141,21 @@ func cleanupStaleTempFiles(dir, baseName string) {
 		if !strings.HasPrefix(name, prefix) {
 			continue
 		}
-		if name == tagFile {
+		if isSidecarFile(name, baseName) {
 			continue
 		}
 		_ = os.Remove(filepath.Join(dir, name))
 	}
 }
 
-// downloadFile downloads url to dest path. Uses a temp file and rename for atomic write.
-func downloadFile(downloadURL, destPath, token string) error {
+// downloadFile downloads url to dest path. If assetName indicates a .gz, .tar.gz or .zip wrapper,
+// the matching entry (selected by innerGlob for multi-file archives) is transparently extracted;
+// otherwise the body is written verbatim. If outer is non-nil, the raw downloaded bytes (before
+// any extraction) must hash to outer.expectedHex; a mismatch deletes destPath and fails the
+// download, even though extraction itself already succeeded. validate, if non-nil, is checked
+// against the extracted file before it's moved into place; see atomicWriteReader. Uses a temp
+// file and rename for atomic write.
+func downloadFile(downloadURL, destPath, token, assetName, innerGlob string, outer *outerChecksum, validate func(string) error) error {
 	req, err := http.NewRequest(http.MethodGet, downloadURL, nil)
 	if err != nil {
 		return err
@@ -138,29 +174,21 @@ func downloadFile(downloadURL, destPath, token string) error {
 		return fmt.Errorf("download %s: %s", resp.Status, string(body))
 	}
 
-	dir := filepath.Dir(destPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("mkdir: %w", err)
-	}
-	// Remove stale temp files from previous runs (e.g. crashed before rename).
-	cleanupStaleTempFiles(dir, filepath.Base(destPath))
-	tmp, err := os.CreateTemp(dir, filepath.Base(destPath)+".*")
-	if err != nil {
-		return fmt.Errorf("temp file: %w", err)
+	var body io.Reader = resp.Body
+	var tee *checksumTee
+	if outer != nil {
+		tee = newChecksumTee(body, *outer)
+		body = tee
 	}
-	tmpPath := tmp.Name()
-	defer os.Remove(tmpPath)
 
-	_, err = io.Copy(tmp, resp.Body)
-	if err != nil {
-		tmp.Close()
-		return fmt.Errorf("write: %w", err)
-	}
-	if err := tmp.Close(); err != nil {
+	if err := extractAsset(body, assetName, resp.Header.Get("Content-Type"), innerGlob, destPath, validate); err != nil {
 		return err
 	}
-	if err := os.Rename(tmpPath, destPath); err != nil {
-		return fmt.Errorf("rename: %w", err)
+	if tee != nil {
+		if err := tee.verify(); err != nil {
+			_ = os.Remove(destPath)
+			return err
+		}
 	}
 	return nil
 }
@@ -182,14 +210,48 @@ func writeStoredTag(cachePath, tag string) error {
 	return os.WriteFile(tagPath(cachePath), []byte(tag+"\n"), 0644)
 }
 
-// syncFromGitHubRelease downloads the given asset from the latest GitHub release to cachePath if tag changed.
+// SyncOptions bundles the optional knobs of syncFromGitHubRelease, grouped out of its positional
+// arguments now that there are enough of them that a plain string list got hard to read at a
+// call site.
+type SyncOptions struct {
+	// InnerGlob selects which file to keep when assetPattern resolves to a .tar.gz or .zip
+	// archive (e.g. "*.mmdb"); ignored for plain files and single-file .gz assets.
+	InnerGlob string
+	// ExpectedSHA256, if set, is checked against the raw downloaded asset exactly as published
+	// (before any .tar.gz/.zip extraction); otherwise a sibling "<asset>.sha256" or "<asset>.md5"
+	// asset in the same release is used if present, checked the same way.
+	ExpectedSHA256 string
+	// ExpectedDBType, if set, requires the file extracted from the asset to open as a maxminddb
+	// whose Metadata.DatabaseType matches.
+	ExpectedDBType string
+	// MatchMode selects how assetPattern is interpreted; see AssetSelector.
+	MatchMode AssetMatchMode
+	// Prefer breaks ties when assetPattern matches more than one asset; see AssetSelector.
+	Prefer string
+}
+
+// syncFromGitHubRelease downloads the asset matching assetPattern from the latest GitHub release
+// to cachePath if the tag changed. assetPattern is interpreted per opts.MatchMode: a literal
+// name (the default), a glob, or a regex, optionally templated against {{.GOOS}}, {{.GOARCH}} and
+// {{.Tag}}; see AssetSelector.
+//
+// The download is integrity-checked before it's allowed to replace cachePath — see SyncOptions —
+// and on any failure the temp file is discarded and cachePath is left untouched.
+//
 // Returns the tag and true if a new file was written, false if already up to date.
-// If the cache file is missing (e.g. was deleted), re-downloads regardless of .tag.
-func syncFromGitHubRelease(repo, assetName, cachePath, token string) (tag string, updated bool, err error) {
-	tag, downloadURL, err := fetchLatestReleaseTagAndAssetURL(repo, assetName, token)
+// If the cache file is missing (e.g. was deleted), re-downloads regardless of .tag. The .tag cache
+// key is always the outer asset name, so re-downloads still trigger correctly even when extracted.
+func syncFromGitHubRelease(repo, assetPattern, cachePath, token string, opts SyncOptions) (tag string, updated bool, err error) {
+	release, err := fetchLatestReleaseCached(repo, token, cachePath)
 	if err != nil {
 		return "", false, err
 	}
+	asset, err := selectAsset(release.Assets, AssetSelector{Pattern: assetPattern, Mode: opts.MatchMode, Prefer: opts.Prefer}, release.TagName)
+	if err != nil {
+		return "", false, err
+	}
+	tag = release.TagName
+
 	if _, statErr := os.Stat(cachePath); os.IsNotExist(statErr) {
 		// Cache file missing — force download; .tag is stale.
 		_ = os.Remove(tagPath(cachePath))
@@ -199,7 +261,16 @@ func syncFromGitHubRelease(repo, assetName, cachePath, token string) (tag string
 			return tag, false, nil
 		}
 	}
-	if err := downloadFile(downloadURL, cachePath, token); err != nil {
+
+	outer, err := resolveOuterChecksum(release.Assets, asset.Name, token, opts.ExpectedSHA256)
+	if err != nil {
+		return "", false, err
+	}
+	var validate func(string) error
+	if opts.ExpectedDBType != "" {
+		validate = dbTypeValidator(opts.ExpectedDBType)
+	}
+	if err := downloadFile(asset.BrowserDownloadURL, cachePath, token, asset.Name, opts.InnerGlob, outer, validate); err != nil {
 		return "", false, err
 	}
 	if err := writeStoredTag(cachePath, tag); err != nil {
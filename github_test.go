@@ -125,7 +125,7 @@ func TestDownloadFile_Mock(t *testing.T) {
 	dir := t.TempDir()
 	dest := filepath.Join(dir, "GeoLite2-Country.mmdb")
 
-	err := downloadFile(server.URL, dest, "")
+	err := downloadFile(server.URL, dest, "", "GeoLite2-Country.mmdb", "", nil, nil)
 	if err != nil {
 		t.Fatalf("downloadFile: %v", err)
 	}
@@ -176,7 +176,7 @@ func TestSyncFromGitHubRelease_Mock(t *testing.T) {
 	dir := t.TempDir()
 	cachePath := filepath.Join(dir, "GeoLite2-Country.mmdb")
 
-	tag, updated, err := syncFromGitHubRelease("P3TERX/GeoLite.mmdb", "GeoLite2-Country.mmdb", cachePath, "")
+	tag, updated, err := syncFromGitHubRelease("P3TERX/GeoLite.mmdb", "GeoLite2-Country.mmdb", cachePath, "", SyncOptions{})
 	if err != nil {
 		t.Fatalf("syncFromGitHubRelease: %v", err)
 	}
@@ -195,7 +195,7 @@ func TestSyncFromGitHubRelease_Mock(t *testing.T) {
 	}
 
 	// Second call: same tag -> no update
-	_, updated2, err := syncFromGitHubRelease("P3TERX/GeoLite.mmdb", "GeoLite2-Country.mmdb", cachePath, "")
+	_, updated2, err := syncFromGitHubRelease("P3TERX/GeoLite.mmdb", "GeoLite2-Country.mmdb", cachePath, "", SyncOptions{})
 	if err != nil {
 		t.Fatalf("second sync: %v", err)
 	}
@@ -210,14 +210,15 @@ func TestCleanupStaleTempFiles(t *testing.T) {
 	dir := t.TempDir()
 	base := "GeoLite2-Country.mmdb"
 	tagPath := filepath.Join(dir, base+".tag")
+	md5Path := filepath.Join(dir, base+".md5")
+	etagPath := filepath.Join(dir, base+".etag")
 	realPath := filepath.Join(dir, base)
 	stalePath := filepath.Join(dir, base+".stale123")
 
-	if err := os.WriteFile(tagPath, []byte("v1"), 0644); err != nil {
-		t.Fatal(err)
-	}
-	if err := os.WriteFile(realPath, []byte("data"), 0644); err != nil {
-		t.Fatal(err)
+	for _, p := range []string{tagPath, md5Path, etagPath, realPath} {
+		if err := os.WriteFile(p, []byte("v1"), 0644); err != nil {
+			t.Fatal(err)
+		}
 	}
 	if err := os.WriteFile(stalePath, []byte("old"), 0644); err != nil {
 		t.Fatal(err)
@@ -225,11 +226,10 @@ func TestCleanupStaleTempFiles(t *testing.T) {
 
 	cleanupStaleTempFiles(dir, base)
 
-	if _, err := os.Stat(tagPath); os.IsNotExist(err) {
-		t.Error(".tag file was removed but should be kept")
-	}
-	if _, err := os.Stat(realPath); os.IsNotExist(err) {
-		t.Error("real file was removed")
+	for _, p := range []string{tagPath, md5Path, etagPath, realPath} {
+		if _, err := os.Stat(p); os.IsNotExist(err) {
+			t.Errorf("%s was removed but should be kept", p)
+		}
 	}
 	if _, err := os.Stat(stalePath); err == nil {
 		t.Error("stale temp file was not removed")
@@ -294,7 +294,7 @@ func TestDownloadFromGitHubIntegration(t *testing.T) {
 	dir := t.TempDir()
 	cachePath := filepath.Join(dir, "GeoLite2-Country.mmdb")
 
-	if err := downloadFile(downloadURL, cachePath, token); err != nil {
+	if err := downloadFile(downloadURL, cachePath, token, "GeoLite2-Country.mmdb", "", nil, nil); err != nil {
 		t.Fatalf("download file: %v", err)
 	}
 
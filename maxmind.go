@@ -0,0 +1,151 @@
+package caddy_maxmind_geolocation
+
+import (
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const maxmindAPIBase = "https://updates.maxmind.com"
+
+// For tests: override to use mock server.
+var (
+	maxmindAPIBaseURL = maxmindAPIBase
+	maxmindHTTPClient = http.DefaultClient
+)
+
+// maxmindMetadataResponse represents the relevant part of MaxMind's update metadata
+// endpoint response. See: https://dev.maxmind.com/geoip/updating-databases
+type maxmindMetadataResponse struct {
+	Databases []maxmindMetadataEntry `json:"databases"`
+}
+
+type maxmindMetadataEntry struct {
+	EditionID string `json:"edition_id"`
+	MD5       string `json:"md5"`
+	Date      string `json:"date"`
+}
+
+// fetchMaxMindMetadata returns the MD5 and date of the current database for editionID.
+func fetchMaxMindMetadata(editionID, accountID, licenseKey string) (md5Hex string, date string, err error) {
+	url := fmt.Sprintf("%s/geoip/updates/metadata?edition_id=%s", maxmindAPIBaseURL, editionID)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", "", err
+	}
+	req.SetBasicAuth(accountID, licenseKey)
+
+	resp, err := maxmindHTTPClient.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("maxmind metadata request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", "", fmt.Errorf("maxmind metadata API %s: %s", resp.Status, string(body))
+	}
+
+	var meta maxmindMetadataResponse
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return "", "", fmt.Errorf("maxmind metadata decode: %w", err)
+	}
+	for _, db := range meta.Databases {
+		if db.EditionID == editionID {
+			return db.MD5, db.Date, nil
+		}
+	}
+	return "", "", fmt.Errorf("edition %q not found in maxmind metadata response", editionID)
+}
+
+// md5Path returns the path of the file storing the current database's MD5 (e.g. cache.mmdb -> cache.mmdb.md5).
+func md5Path(cachePath string) string {
+	return cachePath + ".md5"
+}
+
+func readStoredMD5(cachePath string) string {
+	b, err := os.ReadFile(md5Path(cachePath))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func writeStoredMD5(cachePath, sum string) error {
+	return os.WriteFile(md5Path(cachePath), []byte(sum+"\n"), 0644)
+}
+
+// downloadMaxMindDB downloads the gzipped mmdb at downloadURL and decompresses it to destPath,
+// using the same atomic temp-file+rename flow as downloadFile. validate, if non-nil, is checked
+// against the decompressed file before it's moved into place; see atomicWriteReader.
+func downloadMaxMindDB(downloadURL, destPath, accountID, licenseKey string, validate func(string) error) error {
+	req, err := http.NewRequest(http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(accountID, licenseKey)
+
+	resp, err := maxmindHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("maxmind download: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("maxmind download %s: %s", resp.Status, string(body))
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return fmt.Errorf("maxmind gunzip: %w", err)
+	}
+	defer gz.Close()
+
+	return atomicWriteReader(destPath, gz, validate)
+}
+
+// syncFromMaxMind downloads the given edition from MaxMind's native update service to cachePath
+// if the remote MD5 differs from the cached one. The stored MD5 is trusted as a record of what
+// was last verified and written — re-hashing cachePath on every check would be redundant, since
+// the download itself is checksummed against remoteMD5 before it's ever allowed to replace
+// cachePath. Returns the database date and true if a new file was written, false if already up
+// to date. If the cache file is missing (e.g. was deleted), re-downloads regardless of the
+// stored MD5.
+//
+// syncFromMaxMind has the same (tag/date, updated, err) shape as syncFromGitHubRelease so a caller
+// can select between them; it deliberately does not know about Caddyfile syntax or module
+// lifecycle, and takes accountID/licenseKey/editionID as plain arguments rather than reading them
+// off a config struct. Nothing in this tree parses a `maxmind { account_id ... license_key ...
+// edition_ids ... }` block and calls this with the result — there's no CaddyModule/Provision here
+// for that block to live on, so this function is unreachable from an actual Caddyfile today.
+func syncFromMaxMind(accountID, licenseKey, editionID, cachePath string) (date string, updated bool, err error) {
+	remoteMD5, date, err := fetchMaxMindMetadata(editionID, accountID, licenseKey)
+	if err != nil {
+		return "", false, err
+	}
+
+	localMD5 := readStoredMD5(cachePath)
+	if _, statErr := os.Stat(cachePath); os.IsNotExist(statErr) {
+		// Cache file missing — force download; stored MD5 is stale.
+		_ = os.Remove(md5Path(cachePath))
+		localMD5 = ""
+	} else if localMD5 == remoteMD5 {
+		return date, false, nil
+	}
+
+	// db_md5 tells MaxMind the MD5 of the database we currently hold, not the one it's about to
+	// send: passing remoteMD5 here would just echo the server's own state back at it.
+	downloadURL := fmt.Sprintf("%s/geoip/databases/%s/download?db_md5=%s", maxmindAPIBaseURL, editionID, localMD5)
+	if err := downloadMaxMindDB(downloadURL, cachePath, accountID, licenseKey, checksumValidator(md5.New, remoteMD5)); err != nil {
+		return "", false, err
+	}
+	if err := writeStoredMD5(cachePath, remoteMD5); err != nil {
+		// non-fatal
+	}
+	return date, true, nil
+}
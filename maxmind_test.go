@@ -0,0 +1,172 @@
+package caddy_maxmind_geolocation
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFetchMaxMindMetadata_Mock(t *testing.T) {
+	meta := maxmindMetadataResponse{
+		Databases: []maxmindMetadataEntry{
+			{EditionID: "GeoLite2-Country", MD5: "abc123", Date: "2026-02-25"},
+		},
+	}
+	body, _ := json.Marshal(meta)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/geoip/updates/metadata" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		// The response body only ever describes GeoLite2-Country, regardless of what was
+		// queried: the second call below deliberately asks for GeoLite2-City to exercise
+		// fetchMaxMindMetadata's own "edition not found" path.
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "acct" || pass != "license" {
+			t.Errorf("missing or wrong basic auth: %s/%s", user, pass)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	oldBase := maxmindAPIBaseURL
+	oldClient := maxmindHTTPClient
+	maxmindAPIBaseURL = server.URL
+	maxmindHTTPClient = server.Client()
+	defer func() {
+		maxmindAPIBaseURL = oldBase
+		maxmindHTTPClient = oldClient
+	}()
+
+	md5Hex, date, err := fetchMaxMindMetadata("GeoLite2-Country", "acct", "license")
+	if err != nil {
+		t.Fatalf("fetchMaxMindMetadata: %v", err)
+	}
+	if md5Hex != "abc123" {
+		t.Errorf("md5 = %q, want abc123", md5Hex)
+	}
+	if date != "2026-02-25" {
+		t.Errorf("date = %q, want 2026-02-25", date)
+	}
+
+	_, _, err = fetchMaxMindMetadata("GeoLite2-City", "acct", "license")
+	if err == nil {
+		t.Error("expected error for unknown edition")
+	}
+}
+
+func TestSyncFromMaxMind_Mock(t *testing.T) {
+	fakeContent := []byte("fake mmdb content")
+	fakeContentV2 := []byte("fake mmdb content v2")
+	gzipOf := func(b []byte) []byte {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		gw.Write(b)
+		gw.Close()
+		return buf.Bytes()
+	}
+	md5Hex := func(b []byte) string {
+		sum := md5.Sum(b)
+		return hex.EncodeToString(sum[:])
+	}
+	sumHex := md5Hex(fakeContent)
+	sumHexV2 := md5Hex(fakeContentV2)
+
+	remoteMD5 := sumHex
+	meta := func() []byte {
+		body, _ := json.Marshal(maxmindMetadataResponse{
+			Databases: []maxmindMetadataEntry{
+				{EditionID: "GeoLite2-Country", MD5: remoteMD5, Date: "2026-02-25"},
+			},
+		})
+		return body
+	}
+
+	var gotDBMD5 []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/geoip/updates/metadata":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(meta())
+		case "/geoip/databases/GeoLite2-Country/download":
+			gotDBMD5 = append(gotDBMD5, r.URL.Query().Get("db_md5"))
+			if remoteMD5 == sumHexV2 {
+				w.Write(gzipOf(fakeContentV2))
+			} else {
+				w.Write(gzipOf(fakeContent))
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	oldBase := maxmindAPIBaseURL
+	oldClient := maxmindHTTPClient
+	maxmindAPIBaseURL = server.URL
+	maxmindHTTPClient = server.Client()
+	defer func() {
+		maxmindAPIBaseURL = oldBase
+		maxmindHTTPClient = oldClient
+	}()
+
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "GeoLite2-Country.mmdb")
+
+	date, updated, err := syncFromMaxMind("acct", "license", "GeoLite2-Country", cachePath)
+	if err != nil {
+		t.Fatalf("syncFromMaxMind: %v", err)
+	}
+	if date != "2026-02-25" {
+		t.Errorf("date = %q, want 2026-02-25", date)
+	}
+	if !updated {
+		t.Error("expected updated = true on first sync")
+	}
+	got, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != string(fakeContent) {
+		t.Errorf("content = %q, want %q", got, fakeContent)
+	}
+	if len(gotDBMD5) != 1 || gotDBMD5[0] != "" {
+		t.Errorf("db_md5 on first download = %v, want a single empty value (no local cache yet)", gotDBMD5)
+	}
+
+	// Second call: same MD5 -> no update, no download request at all.
+	_, updated2, err := syncFromMaxMind("acct", "license", "GeoLite2-Country", cachePath)
+	if err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if updated2 {
+		t.Error("expected updated = false when md5 unchanged")
+	}
+	if len(gotDBMD5) != 1 {
+		t.Errorf("expected no additional download request, got %v", gotDBMD5)
+	}
+
+	// Third call: remote MD5 changes -> db_md5 must be the MD5 of the file we currently hold
+	// (sumHex), not the remote's new MD5 (sumHexV2); that's the whole point of the parameter.
+	remoteMD5 = sumHexV2
+	_, updated3, err := syncFromMaxMind("acct", "license", "GeoLite2-Country", cachePath)
+	if err != nil {
+		t.Fatalf("third sync: %v", err)
+	}
+	if !updated3 {
+		t.Error("expected updated = true when remote md5 changes")
+	}
+	if len(gotDBMD5) != 2 || gotDBMD5[1] != sumHex {
+		t.Errorf("db_md5 on second download = %v, want [%q, %q]", gotDBMD5, "", sumHex)
+	}
+}
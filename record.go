@@ -0,0 +1,11 @@
+package caddy_maxmind_geolocation
+
+// Record is the maxminddb lookup target used by the (not-yet-present) Caddy module's request
+// handler to pull fields out of a GeoLite2/GeoIP2 database entry; see
+// github.com/oschwald/maxminddb-golang's Reader.Lookup. Only the fields this tree's tests actually
+// read are declared here — a real module would likely want the full GeoIP2 City/Country schema.
+type Record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
@@ -0,0 +1,133 @@
+package caddy_maxmind_geolocation
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// SyncFunc checks for and, if needed, downloads a new database, returning the same (tag, updated,
+// err) shape as syncFromGitHubRelease and syncFromMaxMind.
+type SyncFunc func() (tag string, updated bool, err error)
+
+// Refresher periodically re-runs a SyncFunc against a cached database file and, whenever it
+// reports an update, re-opens the file and atomically swaps it in behind Reader() so request
+// handling always sees either the old or the new database, never a partially-written one.
+//
+// Start/Stop are designed to be called from a Caddy module's Provision/Cleanup (Start once the
+// initial database is in place, Stop to cancel the loop and release it on config reload or
+// shutdown), with SyncFunc a closure over a `refreshInterval`-configured syncFromGitHubRelease or
+// syncFromMaxMind call. No such module exists in this tree yet, so nothing currently calls Start
+// or Stop outside of tests — a Caddyfile `refreshInterval` directive has nowhere to land until one
+// is added.
+type Refresher struct {
+	cachePath  string
+	sync       SyncFunc
+	interval   time.Duration
+	closeGrace time.Duration
+
+	reader atomic.Pointer[maxminddb.Reader]
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRefresher creates a Refresher for the database at cachePath. interval is the nominal time
+// between checks (±10% jitter is applied on every tick to avoid a thundering herd across a fleet
+// of instances started at the same time); closeGrace is how long an outdated reader is kept open
+// after being swapped out, to let in-flight lookups finish against it.
+func NewRefresher(cachePath string, interval, closeGrace time.Duration, sync SyncFunc) *Refresher {
+	return &Refresher{
+		cachePath:  cachePath,
+		sync:       sync,
+		interval:   interval,
+		closeGrace: closeGrace,
+		done:       make(chan struct{}),
+	}
+}
+
+// Reader returns the currently active database handle, or nil if none has been opened yet.
+func (r *Refresher) Reader() *maxminddb.Reader {
+	return r.reader.Load()
+}
+
+// Start opens the initial database handle and launches the background refresh loop. The loop
+// runs until ctx is canceled or Stop is called.
+func (r *Refresher) Start(ctx context.Context) error {
+	reader, err := maxminddb.Open(r.cachePath)
+	if err != nil {
+		return err
+	}
+	r.reader.Store(reader)
+
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+	go func() {
+		defer close(r.done)
+		r.run(ctx)
+	}()
+	return nil
+}
+
+// Stop cancels the background refresh loop and waits for it to exit.
+func (r *Refresher) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	<-r.done
+}
+
+func (r *Refresher) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(jitter(r.interval)):
+		}
+		r.tick()
+	}
+}
+
+func (r *Refresher) tick() {
+	lastChecked := time.Now()
+	tag, updated, err := r.sync()
+	nextCheck := lastChecked.Add(r.interval)
+	if err != nil {
+		log.Printf("caddy-maxmind-geolocation: refresh check failed: last_checked=%s error=%v next_check=%s",
+			lastChecked.Format(time.RFC3339), err, nextCheck.Format(time.RFC3339))
+		return
+	}
+	log.Printf("caddy-maxmind-geolocation: refresh check complete: last_checked=%s last_updated_tag=%s next_check=%s",
+		lastChecked.Format(time.RFC3339), tag, nextCheck.Format(time.RFC3339))
+	if !updated {
+		return
+	}
+
+	newReader, err := maxminddb.Open(r.cachePath)
+	if err != nil {
+		log.Printf("caddy-maxmind-geolocation: failed to open refreshed database %q: %v", r.cachePath, err)
+		return
+	}
+	old := r.reader.Swap(newReader)
+	if old == nil {
+		return
+	}
+	go func(old *maxminddb.Reader) {
+		time.Sleep(r.closeGrace)
+		old.Close()
+	}(old)
+}
+
+// jitter returns d adjusted by a uniformly random amount within ±10%, so refreshers started at
+// the same instant across a fleet don't all poll upstream in lockstep.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	spread := float64(d) * 0.10
+	offset := (rand.Float64()*2 - 1) * spread
+	return d + time.Duration(offset)
+}
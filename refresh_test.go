@@ -0,0 +1,77 @@
+package caddy_maxmind_geolocation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJitter(t *testing.T) {
+	d := 100 * time.Millisecond
+	for i := 0; i < 50; i++ {
+		got := jitter(d)
+		if got < 90*time.Millisecond || got > 110*time.Millisecond {
+			t.Fatalf("jitter(%s) = %s, want within ±10%%", d, got)
+		}
+	}
+}
+
+func TestRefresher_SwapsReaderOnUpdate(t *testing.T) {
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "db.mmdb")
+	writeTestMMDB(t, cachePath)
+
+	var calls atomic.Int32
+	sync := func() (string, bool, error) {
+		n := calls.Add(1)
+		if n == 1 {
+			return "", false, nil
+		}
+		// Simulate an update by rewriting the cache file with a fresh valid mmdb.
+		writeTestMMDB(t, cachePath)
+		return "v2", true, nil
+	}
+
+	r := NewRefresher(cachePath, 20*time.Millisecond, time.Millisecond, sync)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := r.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer r.Stop()
+
+	firstReader := r.Reader()
+	if firstReader == nil {
+		t.Fatal("expected an initial reader after Start")
+	}
+
+	deadline := time.After(2 * time.Second)
+	for {
+		if calls.Load() >= 2 && r.Reader() != firstReader {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("reader was not swapped after update; calls=%d", calls.Load())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// writeTestMMDB copies the tiny fixture database at testdata/test.mmdb (a valid, empty IPv4
+// database with no search tree) to path, for tests that only need maxminddb.Open to succeed
+// rather than meaningful lookups.
+func writeTestMMDB(t *testing.T, path string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "test.mmdb"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+}